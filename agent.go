@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	useAgent      bool
+	noDisk        bool
+	agentConfirm  bool
+	agentLifetime int
+)
+
+// addToAgent connects to $SSH_AUTH_SOCK and adds priv, with a comment
+// derived from whichever rule matched and an optional lifetime/confirmation
+// requirement, so the operator can later find it via `ssh-add -l`.
+func addToAgent(priv ed25519.PrivateKey, comment string) error {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return fmt.Errorf("SSH_AUTH_SOCK is not set")
+	}
+
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return fmt.Errorf("dial ssh-agent: %w", err)
+	}
+	defer conn.Close()
+
+	key := agent.AddedKey{
+		PrivateKey:       priv,
+		Comment:          comment,
+		ConfirmBeforeUse: agentConfirm,
+	}
+	if agentLifetime > 0 {
+		key.LifetimeSecs = uint32(agentLifetime)
+	}
+	return agent.NewClient(conn).Add(key)
+}
+
+func sshFingerprint(pub ed25519.PublicKey) string {
+	sshPubKey, _ := ssh.NewPublicKey(pub)
+	return ssh.FingerprintSHA256(sshPubKey)
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	serveAddr   string
+	connectAddr string
+	tlsCertFile string
+	tlsKeyFile  string
+	connectTLS  bool
+	tlsCAFile   string
+	sharedToken string
+)
+
+// hexPattern carries a pattern/mask pair across the wire in full, so an
+// odd-length hex pattern's mask (e.g. "abc" -> pattern=ab,c0 mask=ff,f0)
+// survives the round trip instead of being reconstructed by re-parsing a
+// string, which would mistake the padded trailing nibble for a real one.
+type hexPattern struct {
+	Pattern string `json:"pattern"`
+	Mask    string `json:"mask"`
+}
+
+// frame is a length-prefixed JSON message exchanged between the
+// coordinator and a worker connection over -serve/-connect.
+type frame struct {
+	Type string `json:"type"`
+
+	// hello: worker -> coordinator, the first frame sent on a connection
+	Token string `json:"token,omitempty"`
+
+	// assign: coordinator -> worker
+	Suffixes    []string     `json:"suffixes,omitempty"`
+	PrefixHex   []hexPattern `json:"prefix_hex,omitempty"`
+	ContainsHex []hexPattern `json:"contains_hex,omitempty"`
+	IgnoreCase  bool         `json:"ignore_case,omitempty"`
+	WorkerID    string       `json:"worker_id,omitempty"`
+
+	// stats: worker -> coordinator, once a second
+	Counter int64 `json:"counter,omitempty"`
+
+	// hit: worker -> coordinator
+	PubKey     string `json:"pub_key,omitempty"`
+	PrivKeyPEM string `json:"priv_key_pem,omitempty"`
+	Label      string `json:"label,omitempty"`
+}
+
+const (
+	frameHello  = "hello"
+	frameAssign = "assign"
+	frameStats  = "stats"
+	frameHit    = "hit"
+)
+
+// maxFrameSize bounds the length prefix readFrame will trust: the biggest
+// legitimate frame is a hit carrying a PEM private key, nowhere near this.
+const maxFrameSize = 1 << 20 // 1MiB
+
+func writeFrame(w io.Writer, f frame) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(len(b))); err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func readFrame(r io.Reader) (frame, error) {
+	var f frame
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return f, err
+	}
+	if length > maxFrameSize {
+		return f, fmt.Errorf("frame of %d bytes exceeds the %d byte limit", length, maxFrameSize)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return f, err
+	}
+	err := json.Unmarshal(buf, &f)
+	return f, err
+}
+
+// runServe accepts worker connections, hands each one the suffix/pattern
+// set to search, and aggregates their counter/hit reports into the
+// existing ticker display.
+func runServe(addr string, matchers []Matcher, suffixes []string) {
+	if sharedToken == "" {
+		log.Fatal("-serve requires -token, so a worker can't connect and upload hits without it")
+	}
+
+	var listener net.Listener
+	var err error
+	if tlsCertFile != "" || tlsKeyFile != "" {
+		cert, cerr := tls.LoadX509KeyPair(tlsCertFile, tlsKeyFile)
+		if cerr != nil {
+			log.Fatalf("Failed to load TLS cert/key: %v", cerr)
+		}
+		listener, err = tls.Listen("tcp", addr, &tls.Config{Certificates: []tls.Certificate{cert}})
+	} else {
+		listener, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", addr, err)
+	}
+	log.Printf("Coordinator listening on %s (TLS: %v)", addr, tlsCertFile != "")
+
+	c := &coordinator{
+		counters: make(map[string]int64),
+		prev:     make(map[string]int64),
+		seen:     make(map[string]bool),
+	}
+
+	go c.reportLoop()
+
+	nextWorkerID := 0
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("Accept failed: %v", err)
+			continue
+		}
+		nextWorkerID++
+		workerID := fmt.Sprintf("worker-%d", nextWorkerID)
+		go c.handleWorker(conn, workerID, matchers, suffixes)
+	}
+}
+
+type coordinator struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	prev     map[string]int64
+	seen     map[string]bool
+	found    int64
+}
+
+func (c *coordinator) handleWorker(conn net.Conn, workerID string, matchers []Matcher, suffixes []string) {
+	defer conn.Close()
+	log.Printf("%s connected from %s", workerID, conn.RemoteAddr())
+
+	if sharedToken != "" {
+		hello, err := readFrame(conn)
+		if err != nil || hello.Type != frameHello || hello.Token != sharedToken {
+			log.Printf("%s: rejected, missing or wrong -token", workerID)
+			return
+		}
+	}
+
+	assign := frame{
+		Type:       frameAssign,
+		Suffixes:   suffixes,
+		IgnoreCase: ignoreCase,
+		WorkerID:   workerID,
+	}
+	for _, m := range matchers {
+		switch mm := m.(type) {
+		case maskMatcher:
+			assign.PrefixHex = append(assign.PrefixHex, hexPattern{
+				Pattern: hex.EncodeToString(mm.pattern),
+				Mask:    hex.EncodeToString(mm.mask),
+			})
+		case containsHexMatcher:
+			assign.ContainsHex = append(assign.ContainsHex, hexPattern{
+				Pattern: hex.EncodeToString(mm.pattern),
+				Mask:    hex.EncodeToString(mm.mask),
+			})
+		}
+	}
+	if err := writeFrame(conn, assign); err != nil {
+		log.Printf("%s: failed to send assignment: %v", workerID, err)
+		return
+	}
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.counters, workerID)
+		delete(c.prev, workerID)
+		c.mu.Unlock()
+		log.Printf("%s disconnected", workerID)
+	}()
+
+	for {
+		f, err := readFrame(conn)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("%s: connection error: %v", workerID, err)
+			}
+			return
+		}
+
+		switch f.Type {
+		case frameStats:
+			c.mu.Lock()
+			c.counters[workerID] = f.Counter
+			c.mu.Unlock()
+
+		case frameHit:
+			c.mu.Lock()
+			dup := c.seen[f.PubKey]
+			if !dup {
+				c.seen[f.PubKey] = true
+				c.found++
+			}
+			c.mu.Unlock()
+			if dup {
+				log.Printf("%s: duplicate hit for %s, ignoring", workerID, f.PubKey)
+				continue
+			}
+			if err := c.writeHit(f); err != nil {
+				log.Printf("%s: failed to persist hit: %v", workerID, err)
+				continue
+			}
+			log.Printf("\r\x1b[KFound %s (%s) from %s", f.PubKey, f.Label, workerID)
+		}
+	}
+}
+
+func (c *coordinator) writeHit(f frame) error {
+	keyFile := fmt.Sprintf("%d.key", time.Now().UnixNano())
+	if err := os.WriteFile(keyFile, []byte(f.PrivKeyPEM), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(keyFile+".pub", []byte(f.PubKey+"\n"), 0644)
+}
+
+// reportLoop prints the aggregated per-worker and total rate every 10
+// seconds, matching the cadence of the single-machine ticker.
+func (c *coordinator) reportLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		c.mu.Lock()
+		var ids []string
+		var total float64
+		var parts []string
+		for id := range c.counters {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			rate := float64(c.counters[id]-c.prev[id]) / 10
+			c.prev[id] = c.counters[id]
+			total += rate
+			parts = append(parts, fmt.Sprintf("%s %s/s", id, humanScale(rate)))
+		}
+		hits := c.found
+		c.mu.Unlock()
+
+		fmt.Printf("\r\x1b[K%s, total %s/s, hit %d\n", joinOrNone(parts), humanScale(total), hits)
+	}
+}
+
+func joinOrNone(parts []string) string {
+	if len(parts) == 0 {
+		return "no workers connected"
+	}
+	out := parts[0]
+	for _, p := range parts[1:] {
+		out += ", " + p
+	}
+	return out
+}
+
+// decodeHexPattern decodes a hexPattern sent by the coordinator, trusting
+// its mask as-is rather than re-deriving it from the pattern string (which
+// can't distinguish a real trailing zero nibble from a padded one).
+func decodeHexPattern(hp hexPattern) (pattern, mask []byte, err error) {
+	pattern, err = hex.DecodeString(hp.Pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid hex pattern %q: %w", hp.Pattern, err)
+	}
+	mask, err = hex.DecodeString(hp.Mask)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid hex mask %q: %w", hp.Mask, err)
+	}
+	return pattern, mask, nil
+}
+
+// runConnect runs worker-only: it dials the coordinator, receives its
+// assignment, searches using the same worker loop as standalone mode, and
+// streams counters and uploads hits instead of writing to disk locally.
+func runConnect(addr string) {
+	passphrase := []byte(os.Getenv("PASSPHRASE"))
+	if !connectTLS && len(passphrase) == 0 {
+		log.Fatal("-connect requires -tls or PASSPHRASE, so the uploaded private key isn't sent in the clear")
+	}
+
+	var conn net.Conn
+	var err error
+	if connectTLS {
+		if tlsCAFile == "" {
+			log.Fatal("-tls requires -tls-ca to verify the coordinator's certificate")
+		}
+		caPEM, rerr := os.ReadFile(tlsCAFile)
+		if rerr != nil {
+			log.Fatalf("Failed to read -tls-ca: %v", rerr)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			log.Fatalf("No certificates found in -tls-ca %s", tlsCAFile)
+		}
+		serverName, _, serr := net.SplitHostPort(addr)
+		if serr != nil {
+			serverName = addr
+		}
+		conn, err = tls.Dial("tcp", addr, &tls.Config{RootCAs: pool, ServerName: serverName})
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		log.Fatalf("Failed to connect to %s: %v", addr, err)
+	}
+	defer conn.Close()
+
+	if sharedToken != "" {
+		if err := writeFrame(conn, frame{Type: frameHello, Token: sharedToken}); err != nil {
+			log.Fatalf("Failed to send hello: %v", err)
+		}
+	}
+
+	assign, err := readFrame(conn)
+	if err != nil || assign.Type != frameAssign {
+		log.Fatalf("Failed to read assignment: %v", err)
+	}
+
+	var matchers []Matcher
+	for _, s := range assign.Suffixes {
+		matchers = append(matchers, suffixBase64Matcher{suffix: s})
+	}
+	for _, p := range assign.PrefixHex {
+		pattern, mask, perr := decodeHexPattern(p)
+		if perr != nil {
+			log.Fatal(perr)
+		}
+		matchers = append(matchers, maskMatcher{pattern: pattern, mask: mask})
+	}
+	for _, cpat := range assign.ContainsHex {
+		pattern, mask, perr := decodeHexPattern(cpat)
+		if perr != nil {
+			log.Fatal(perr)
+		}
+		matchers = append(matchers, containsHexMatcher{pattern: pattern, mask: mask})
+	}
+	ignoreCase = assign.IgnoreCase
+
+	log.Printf("Connected as %s, searching with %d worker(s)", assign.WorkerID, numWorkers)
+
+	var counter int64
+	var sendMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			w, err := newWorker()
+			if err != nil {
+				log.Printf("Failed to initialize worker: %v", err)
+				return
+			}
+
+			for {
+				pub, priv, err := ed25519.GenerateKey(w.rng)
+				if err != nil {
+					log.Printf("Error generating keys: %v", err)
+					continue
+				}
+				atomic.AddInt64(&counter, 1)
+
+				m, ok := firstMatch(matchers, pub)
+				if !ok {
+					continue
+				}
+
+				hit := frame{
+					Type:       frameHit,
+					PubKey:     publicKeyString(pub),
+					PrivKeyPEM: string(privateKeyPEM(priv, passphrase)),
+					Label:      m.Label(),
+				}
+				sendMu.Lock()
+				err = writeFrame(conn, hit)
+				sendMu.Unlock()
+				if err != nil {
+					log.Printf("Failed to upload hit: %v", err)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			sendMu.Lock()
+			err := writeFrame(conn, frame{Type: frameStats, WorkerID: assign.WorkerID, Counter: atomic.LoadInt64(&counter)})
+			sendMu.Unlock()
+			if err != nil {
+				log.Fatalf("Lost connection to coordinator: %v", err)
+			}
+		}
+	}()
+
+	wg.Wait()
+}
@@ -9,8 +9,8 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"os"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -61,25 +61,99 @@ func (r *chacha20Reader) Read(p []byte) (int, error) {
 	return len(p), nil
 }
 
+var (
+	prefixHexFlags   hexFlagList
+	containsHexFlags hexFlagList
+)
+
 func main() {
 	flag.BoolVar(&ignoreCase, "i", false, "Ignore case when comparing the suffix")
 	flag.IntVar(&numWorkers, "n", 1, "Number of workers to utilize")
+	flag.BoolVar(&pgpMode, "pgp", false, "Search OpenPGP ed25519 keys by long key-ID suffix instead of SSH keys")
+	flag.StringVar(&pgpUID, "pgp-uid", "sshkeygen <sshkeygen@localhost>", "User ID bound to the -pgp key via a self-signature")
+	flag.Var(&prefixHexFlags, "prefix-hex", "Match if the raw public key starts with this hex pattern (repeatable)")
+	flag.Var(&containsHexFlags, "contains-hex", "Match if the raw public key contains this hex pattern anywhere (repeatable)")
+	flag.BoolVar(&useAgent, "agent", false, "Add the generated key to the ssh-agent at $SSH_AUTH_SOCK on a hit")
+	flag.BoolVar(&noDisk, "no-disk", false, "Skip writing .key/.key.pub once -agent succeeds (requires -agent)")
+	flag.BoolVar(&agentConfirm, "agent-confirm", false, "Require agent confirmation before each use of the added key")
+	flag.IntVar(&agentLifetime, "agent-lifetime", 0, "Seconds before the agent expires the added key (0 = no expiry)")
+	flag.StringVar(&serveAddr, "serve", "", "Run as a search coordinator, listening on :PORT for -connect workers")
+	flag.StringVar(&connectAddr, "connect", "", "Run as a worker-only process against a -serve coordinator at host:port")
+	flag.StringVar(&tlsCertFile, "tls-cert", "", "TLS certificate file for -serve")
+	flag.StringVar(&tlsKeyFile, "tls-key", "", "TLS key file for -serve")
+	flag.BoolVar(&connectTLS, "tls", false, "Use TLS when dialing -connect")
+	flag.StringVar(&tlsCAFile, "tls-ca", "", "CA certificate file used to verify the -serve coordinator when -tls is set")
+	flag.StringVar(&sharedToken, "token", "", "Shared secret a -connect worker must present to this -serve coordinator")
 	flag.Parse()
 	log.SetFlags(0)
 
 	suffixes := flag.Args()
-	if len(suffixes) == 0 {
-		log.Fatalf("Usage: %s [flags] <suffix>...", os.Args[0])
-	}
 	if numWorkers < 1 {
 		log.Fatal("Number of workers must be at least 1")
 	}
+	if noDisk && !useAgent {
+		log.Fatal("-no-disk requires -agent")
+	}
+
+	if connectAddr != "" {
+		runConnect(connectAddr)
+		return
+	}
+
+	if pgpMode {
+		if len(suffixes) == 0 {
+			log.Fatalf("Usage: %s -pgp [flags] <suffix>...", os.Args[0])
+		}
+		runPGP(suffixes)
+		return
+	}
+
+	for _, suffix := range suffixes {
+		if err := validateBase64Suffix(suffix); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var matchers []Matcher
+	for _, s := range suffixes {
+		matchers = append(matchers, suffixBase64Matcher{suffix: s})
+	}
+	for _, p := range prefixHexFlags {
+		pattern, mask, err := parseHexPattern(p)
+		if err != nil {
+			log.Fatal(err)
+		}
+		matchers = append(matchers, maskMatcher{pattern: pattern, mask: mask, offset: 0})
+	}
+	for _, c := range containsHexFlags {
+		pattern, mask, err := parseHexPattern(c)
+		if err != nil {
+			log.Fatal(err)
+		}
+		matchers = append(matchers, containsHexMatcher{pattern: pattern, mask: mask})
+	}
+	if len(matchers) == 0 {
+		log.Fatalf("Usage: %s [flags] <suffix>...", os.Args[0])
+	}
+
+	if serveAddr != "" {
+		runServe(serveAddr, matchers, suffixes)
+		return
+	}
 
 	passphrase := []byte(os.Getenv("PASSPHRASE"))
 	log.Printf("Searching with %d worker(s), case %ssensitive, private key will %s passphrase protected",
 		numWorkers, map[bool]string{true: "in", false: ""}[ignoreCase],
 		map[bool]string{true: "be", false: "NOT be"}[len(passphrase) > 0])
 
+	expected := expectedTries(matchers)
+	rate := benchmarkRate() * float64(numWorkers)
+	eta := "?"
+	if !math.IsInf(expected, 1) {
+		eta = time.Duration(expected / rate * float64(time.Second)).Round(time.Second).String()
+	}
+	log.Printf("Expected ~%s tries per hit, ~%s at current hardware estimate", humanScale(expected), eta)
+
 	var (
 		counter int64
 		found   int64
@@ -106,27 +180,43 @@ func main() {
 				}
 				atomic.AddInt64(&counter, 1)
 
-				pubKey := publicKeyString(pub)
-				if !hasSuffix(pubKey, suffixes) {
+				m, ok := firstMatch(matchers, pub)
+				if !ok {
 					continue
 				}
 
 				atomic.AddInt64(&found, 1)
-				privKey := privateKeyPEM(priv, passphrase)
-
-				keyFile := fmt.Sprintf("%d.key", time.Now().UnixNano())
-				pubFile := keyFile + ".pub"
-
-				if err := os.WriteFile(keyFile, privKey, 0600); err != nil {
-					log.Printf("Failed to write private key: %v", err)
-					continue
+				pubKey := publicKeyString(pub)
+				comment := fmt.Sprintf("sshkeygen %s", m.Label())
+				fingerprint := sshFingerprint(pub)
+
+				addedToAgent := false
+				if useAgent {
+					if err := addToAgent(priv, comment); err != nil {
+						log.Printf("Failed to add key to agent: %v", err)
+					} else {
+						addedToAgent = true
+					}
 				}
-				if err := os.WriteFile(pubFile, []byte(pubKey+"\n"), 0644); err != nil {
-					log.Printf("Failed to write public key: %v", err)
-					continue
+
+				dest := "agent only"
+				if !noDisk || !addedToAgent {
+					privKey := privateKeyPEM(priv, passphrase)
+					keyFile := fmt.Sprintf("%d.key", time.Now().UnixNano())
+					pubFile := keyFile + ".pub"
+
+					if err := os.WriteFile(keyFile, privKey, 0600); err != nil {
+						log.Printf("Failed to write private key: %v", err)
+						continue
+					}
+					if err := os.WriteFile(pubFile, []byte(pubKey+"\n"), 0644); err != nil {
+						log.Printf("Failed to write public key: %v", err)
+						continue
+					}
+					dest = keyFile + "*"
 				}
 				// Clear the current line and move cursor back to the start
-				log.Printf("\r\x1b[KFound %s -> %s*", pubKey, keyFile)
+				log.Printf("\r\x1b[KFound %s [%s] (%s) -> %s", pubKey, fingerprint, comment, dest)
 			}
 		}()
 	}
@@ -139,9 +229,7 @@ func main() {
 		for range ticker.C {
 			elapsed := time.Since(start)
 			hits := atomic.LoadInt64(&found)
-			// Clear the current line and move cursor back to the start
-			fmt.Printf("\r\x1b[KSearched %.1fM key pairs in %s (%.fK pairs/sec), hit %d",
-				float64(counter)/1_000_000, elapsed.Round(time.Second), float64(counter)/1000/elapsed.Seconds(), hits)
+			fmt.Print(progressLine(counter, hits, expected, elapsed))
 		}
 	}()
 
@@ -163,17 +251,3 @@ func privateKeyPEM(priv ed25519.PrivateKey, passphrase []byte) []byte {
 	return pem.EncodeToMemory(block)
 }
 
-func hasSuffix(s string, suffixes []string) bool {
-	if ignoreCase {
-		s = strings.ToLower(s)
-	}
-	for _, suffix := range suffixes {
-		if ignoreCase {
-			suffix = strings.ToLower(suffix)
-		}
-		if strings.HasSuffix(s, suffix) {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Matcher decides whether a freshly generated public key is a hit. Several
+// matchers can be active at once (e.g. a `foo` suffix argument plus
+// `-prefix-hex ab`), in which
+// case a key matches if any one of them does. Label identifies which rule
+// matched, for comments and log lines.
+type Matcher interface {
+	Match(pub ed25519.PublicKey) bool
+	Label() string
+}
+
+// firstMatch returns the first matcher that matches pub, if any.
+func firstMatch(matchers []Matcher, pub ed25519.PublicKey) (Matcher, bool) {
+	for _, m := range matchers {
+		if m.Match(pub) {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// suffixBase64Matcher matches the tail of the SSH "type base64key" string,
+// the original -i suffix behavior.
+type suffixBase64Matcher struct {
+	suffix string
+}
+
+func (m suffixBase64Matcher) Match(pub ed25519.PublicKey) bool {
+	s := publicKeyString(pub)
+	suffix := m.suffix
+	if ignoreCase {
+		s = strings.ToLower(s)
+		suffix = strings.ToLower(suffix)
+	}
+	return strings.HasSuffix(s, suffix)
+}
+
+func (m suffixBase64Matcher) Label() string { return "suffix:" + m.suffix }
+
+// maskMatcher matches a byte range of the raw 32-byte public key against
+// pattern under mask: pub[offset+i]&mask[i] == pattern[i]. This is what
+// -prefix-hex compiles down to, and it naturally supports partial-nibble
+// patterns such as an odd number of hex digits.
+type maskMatcher struct {
+	pattern []byte
+	mask    []byte
+	offset  int
+}
+
+func (m maskMatcher) Match(pub ed25519.PublicKey) bool {
+	if m.offset < 0 || m.offset+len(m.pattern) > len(pub) {
+		return false
+	}
+	for i, p := range m.pattern {
+		if pub[m.offset+i]&m.mask[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+func (m maskMatcher) Label() string { return "prefix:" + hex.EncodeToString(m.pattern) }
+
+// containsHexMatcher matches pattern/mask anywhere in the raw public key,
+// which is what -contains-hex compiles down to.
+type containsHexMatcher struct {
+	pattern []byte
+	mask    []byte
+}
+
+func (m containsHexMatcher) Match(pub ed25519.PublicKey) bool {
+	for offset := 0; offset+len(m.pattern) <= len(pub); offset++ {
+		if (maskMatcher{pattern: m.pattern, mask: m.mask, offset: offset}).Match(pub) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m containsHexMatcher) Label() string { return "contains:" + hex.EncodeToString(m.pattern) }
+
+// parseHexPattern decodes a hex string into a (pattern, mask) pair. An odd
+// number of digits is allowed: the trailing nibble is padded and masked out
+// so only the specified nibbles are compared, e.g. "abc" matches the first
+// byte 0xab and the top nibble 0xc- of the second.
+func parseHexPattern(s string) (pattern, mask []byte, err error) {
+	padded := s
+	lastNibbleOnly := len(s)%2 == 1
+	if lastNibbleOnly {
+		padded += "0"
+	}
+
+	pattern, err = hex.DecodeString(padded)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid hex pattern %q: %w", s, err)
+	}
+
+	mask = make([]byte, len(pattern))
+	for i := range mask {
+		mask[i] = 0xFF
+	}
+	if lastNibbleOnly {
+		mask[len(mask)-1] = 0xF0
+	}
+	return pattern, mask, nil
+}
+
+type hexFlagList []string
+
+func (h *hexFlagList) String() string { return strings.Join(*h, ",") }
+
+func (h *hexFlagList) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/bits"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	pgpPubAlgoEdDSA  = 22 // RFC 4880bis EdDSA
+	pgpMaxTimestamps = 2 * 365 * 86400
+)
+
+// ed25519 OID 1.3.6.1.4.1.11591.15.1
+var pgpCurveOID = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0xda, 0x47, 0x0f, 0x01}
+
+var (
+	pgpMode bool
+	pgpUID  string
+)
+
+// runPGP brute-forces the OpenPGP V4 fingerprint of ed25519 primary keys
+// by stepping the 4-byte creation timestamp rather than regenerating
+// keypairs, and writes an armored secret/public key pair on a hit.
+func runPGP(suffixes []string) {
+	for _, s := range suffixes {
+		if !isHexString(s) {
+			log.Fatalf("Invalid hex suffix %q", s)
+		}
+	}
+
+	passphrase := []byte(os.Getenv("PASSPHRASE"))
+	log.Printf("Searching PGP keys with %d worker(s), case %ssensitive, private key will %s passphrase protected",
+		numWorkers, map[bool]string{true: "in", false: ""}[ignoreCase],
+		map[bool]string{true: "be", false: "NOT be"}[len(passphrase) > 0])
+
+	var (
+		counter int64
+		found   int64
+		wg      sync.WaitGroup
+		start   = time.Now()
+	)
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			w, err := newWorker()
+			if err != nil {
+				log.Printf("Failed to initialize worker: %v", err)
+				return
+			}
+
+			for {
+				pub, priv, err := ed25519.GenerateKey(w.rng)
+				if err != nil {
+					log.Printf("Error generating keys: %v", err)
+					continue
+				}
+
+				ts, fp, ok := searchTimestamp(pub, suffixes, &counter)
+				if !ok {
+					continue
+				}
+
+				atomic.AddInt64(&found, 1)
+
+				keyFile := fmt.Sprintf("%d.pgp.key", time.Now().UnixNano())
+				pubFile := keyFile + ".pub"
+
+				if err := os.WriteFile(keyFile, armorPGPSecretKey(pub, priv, pgpUID, ts, passphrase), 0600); err != nil {
+					log.Printf("Failed to write PGP secret key: %v", err)
+					continue
+				}
+				if err := os.WriteFile(pubFile, armorPGPPublicKey(pub, priv, pgpUID, ts), 0644); err != nil {
+					log.Printf("Failed to write PGP public key: %v", err)
+					continue
+				}
+				// Clear the current line and move cursor back to the start
+				log.Printf("\r\x1b[KFound PGP key %s -> %s*", hex.EncodeToString(fp[:]), keyFile)
+			}
+		}()
+	}
+
+	// Report progress every 10 seconds
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			elapsed := time.Since(start)
+			hits := atomic.LoadInt64(&found)
+			// Clear the current line and move cursor back to the start
+			fmt.Printf("\r\x1b[KSearched %.1fM timestamps in %s (%.fK tries/sec), hit %d",
+				float64(counter)/1_000_000, elapsed.Round(time.Second), float64(counter)/1000/elapsed.Seconds(), hits)
+		}
+	}()
+
+	wg.Wait()
+}
+
+// searchTimestamp builds the public key packet body once and patches its
+// 4-byte creation timestamp in place, walking downward from now, until the
+// resulting V4 fingerprint ends with one of suffixes (hex, case-folded per
+// -i). The same timestamp must be written back into the emitted key for the
+// fingerprint to stay stable.
+func searchTimestamp(pub ed25519.PublicKey, suffixes []string, counter *int64) (uint32, [20]byte, bool) {
+	now := uint32(time.Now().Unix())
+	body := pgpPublicKeyBody(pub, now)
+
+	for ts := now; ts > now-pgpMaxTimestamps; ts-- {
+		patchTimestamp(body, ts)
+		fp := pgpFingerprint(body)
+		atomic.AddInt64(counter, 1)
+		if pgpHasSuffix(fp, suffixes) {
+			return ts, fp, true
+		}
+	}
+	return 0, [20]byte{}, false
+}
+
+// isHexString reports whether s is made up entirely of hex digits, without
+// requiring an even length: pgpHasSuffix compares against the hex-encoded
+// fingerprint as a plain string, so an odd-length suffix like "a" is a
+// perfectly valid (if coarse) search term.
+func isHexString(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func pgpHasSuffix(fp [20]byte, suffixes []string) bool {
+	s := hex.EncodeToString(fp[:])
+	if ignoreCase {
+		s = strings.ToLower(s)
+	}
+	for _, suffix := range suffixes {
+		if ignoreCase {
+			suffix = strings.ToLower(suffix)
+		}
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pgpPublicKeyBody builds the RFC 4880 public-key packet body for an
+// ed25519 primary key: version, creation timestamp, algorithm, curve OID
+// and the MPI-encoded EdDSA point.
+func pgpPublicKeyBody(pub ed25519.PublicKey, ts uint32) []byte {
+	point := append([]byte{0x40}, pub...)
+
+	body := make([]byte, 0, 6+1+len(pgpCurveOID)+2+len(point))
+	body = append(body, 0x04)
+	body = append(body, 0, 0, 0, 0) // timestamp placeholder, patched below
+	body = append(body, pgpPubAlgoEdDSA)
+	body = append(body, byte(len(pgpCurveOID)))
+	body = append(body, pgpCurveOID...)
+	body = append(body, mpiEncode(point)...)
+
+	patchTimestamp(body, ts)
+	return body
+}
+
+func patchTimestamp(body []byte, ts uint32) {
+	body[1] = byte(ts >> 24)
+	body[2] = byte(ts >> 16)
+	body[3] = byte(ts >> 8)
+	body[4] = byte(ts)
+}
+
+// pgpFingerprint computes the RFC 4880 V4 fingerprint: SHA-1 over
+// 0x99 || 2-byte big-endian body length || body.
+func pgpFingerprint(body []byte) [20]byte {
+	h := sha1.New()
+	h.Write([]byte{0x99, byte(len(body) >> 8), byte(len(body))})
+	h.Write(body)
+	var out [20]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// mpiEncode encodes b as an OpenPGP multiprecision integer: a 2-byte
+// big-endian bit count followed by the minimal big-endian byte string.
+func mpiEncode(b []byte) []byte {
+	i := 0
+	for i < len(b) && b[i] == 0 {
+		i++
+	}
+	b = b[i:]
+	if len(b) == 0 {
+		return []byte{0, 0}
+	}
+	bitLen := (len(b)-1)*8 + bits.Len8(b[0])
+	out := make([]byte, 2, 2+len(b))
+	out[0] = byte(bitLen >> 8)
+	out[1] = byte(bitLen)
+	return append(out, b...)
+}
+
+func encodePacketHeader(tag int, length int) []byte {
+	header := []byte{byte(0xC0 | tag)}
+	switch {
+	case length < 192:
+		return append(header, byte(length))
+	case length < 8384:
+		length -= 192
+		return append(header, byte(length>>8)+192, byte(length))
+	default:
+		return append(header, 0xFF,
+			byte(length>>24), byte(length>>16), byte(length>>8), byte(length))
+	}
+}
+
+const (
+	pgpTagPublicKey = 6
+	pgpTagSecretKey = 5
+	pgpTagUserID    = 13
+	pgpTagSignature = 2
+)
+
+func armorPGPPublicKey(pub ed25519.PublicKey, priv ed25519.PrivateKey, uid string, ts uint32) []byte {
+	body := pgpPublicKeyBody(pub, ts)
+	packet := append(encodePacketHeader(pgpTagPublicKey, len(body)), body...)
+	packet = append(packet, pgpSelfCertify(priv, body, uid, ts)...)
+	return pgpArmor("PGP PUBLIC KEY BLOCK", packet)
+}
+
+// armorPGPSecretKey emits the secret-key packet alongside the public-key
+// material it was derived from. When passphrase is non-empty the secret
+// MPI is protected with an iterated+salted S2K and AES-256-CFB, matching
+// the PASSPHRASE convention used for the OpenSSH key writers.
+func armorPGPSecretKey(pub ed25519.PublicKey, priv ed25519.PrivateKey, uid string, ts uint32, passphrase []byte) []byte {
+	pubBody := pgpPublicKeyBody(pub, ts)
+	seed := priv.Seed() // OpenPGP stores only the 32-byte EdDSA seed
+	secretMPI := mpiEncode(seed)
+
+	var tail []byte
+	if len(passphrase) == 0 {
+		checksum := 0
+		for _, b := range secretMPI {
+			checksum += int(b)
+		}
+		tail = append([]byte{0}, secretMPI...)
+		tail = append(tail, byte(checksum>>8), byte(checksum))
+	} else {
+		tail = pgpEncryptSecret(secretMPI, passphrase)
+	}
+
+	body := append(append([]byte{}, pubBody...), tail...)
+	packet := append(encodePacketHeader(pgpTagSecretKey, len(body)), body...)
+	packet = append(packet, pgpSelfCertify(priv, pubBody, uid, ts)...)
+	return pgpArmor("PGP PRIVATE KEY BLOCK", packet)
+}
+
+const (
+	pgpSigTypePositiveCert = 0x13
+	pgpHashAlgoSHA256      = 8
+
+	pgpSubpacketSigCreationTime = 2
+	pgpSubpacketIssuer          = 16
+	pgpSubpacketKeyFlags        = 27
+)
+
+// encodeSubpacket wraps body in a signature subpacket: a one-byte length
+// (covering typ and body, since neither ever nears the 192-byte boundary
+// where RFC 4880's variable-length encoding would kick in) followed by the
+// subpacket type and body.
+func encodeSubpacket(typ byte, body []byte) []byte {
+	out := []byte{byte(len(body) + 1), typ}
+	return append(out, body...)
+}
+
+// pgpSelfCertify builds a User ID packet and a positive-certification
+// self-signature (type 0x13, RFC 4880 section 5.2.4) binding it to the
+// primary key. Without this, GnuPG refuses to import the key at all:
+// "new key but contains no user ID - skipped".
+func pgpSelfCertify(priv ed25519.PrivateKey, keyBody []byte, uid string, ts uint32) []byte {
+	uidBytes := []byte(uid)
+	uidPacket := append(encodePacketHeader(pgpTagUserID, len(uidBytes)), uidBytes...)
+
+	tsBytes := []byte{byte(ts >> 24), byte(ts >> 16), byte(ts >> 8), byte(ts)}
+	hashedSubpackets := append(
+		encodeSubpacket(pgpSubpacketSigCreationTime, tsBytes),
+		encodeSubpacket(pgpSubpacketKeyFlags, []byte{0x01 | 0x02})...) // certify + sign
+
+	sigPrefix := []byte{4, pgpSigTypePositiveCert, pgpPubAlgoEdDSA, pgpHashAlgoSHA256}
+	sigPrefix = append(sigPrefix, byte(len(hashedSubpackets)>>8), byte(len(hashedSubpackets)))
+	sigPrefix = append(sigPrefix, hashedSubpackets...)
+
+	trailer := []byte{4, 0xFF,
+		byte(len(sigPrefix) >> 24), byte(len(sigPrefix) >> 16), byte(len(sigPrefix) >> 8), byte(len(sigPrefix))}
+
+	h := sha256.New()
+	h.Write([]byte{0x99, byte(len(keyBody) >> 8), byte(len(keyBody))})
+	h.Write(keyBody)
+	h.Write([]byte{0xB4, byte(len(uidBytes) >> 24), byte(len(uidBytes) >> 16), byte(len(uidBytes) >> 8), byte(len(uidBytes))})
+	h.Write(uidBytes)
+	h.Write(sigPrefix)
+	h.Write(trailer)
+	digest := h.Sum(nil)
+
+	// OpenPGP EdDSA signatures are produced over the digest bytes (not the
+	// original message), with r and s emitted as separate MPIs.
+	sig := ed25519.Sign(priv, digest)
+	sigMPIs := append(mpiEncode(sig[:32]), mpiEncode(sig[32:])...)
+
+	fp := pgpFingerprint(keyBody)
+	unhashedSubpackets := encodeSubpacket(pgpSubpacketIssuer, fp[12:])
+
+	body := append([]byte{}, sigPrefix...)
+	body = append(body, byte(len(unhashedSubpackets)>>8), byte(len(unhashedSubpackets)))
+	body = append(body, unhashedSubpackets...)
+	body = append(body, digest[0], digest[1])
+	body = append(body, sigMPIs...)
+
+	sigPacket := append(encodePacketHeader(pgpTagSignature, len(body)), body...)
+	return append(uidPacket, sigPacket...)
+}
+
+// pgpEncryptSecret implements the S2K-usage-254 layout: sym algo, S2K
+// specifier, IV, then AES-256-CFB(secretMPI || SHA-1(secretMPI)).
+func pgpEncryptSecret(secretMPI []byte, passphrase []byte) []byte {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		log.Fatalf("Failed to generate S2K salt: %v", err)
+	}
+	const countOctet = 96 // moderate iteration count, RFC 4880 section 3.7.1.3 coding
+	key := s2kIterated(passphrase, salt, countOctet, 32)
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		log.Fatalf("Failed to generate IV: %v", err)
+	}
+
+	h := sha1.Sum(secretMPI)
+	plain := append(append([]byte{}, secretMPI...), h[:]...)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		log.Fatalf("Failed to init AES cipher: %v", err)
+	}
+	cipher.NewCFBEncrypter(block, iv).XORKeyStream(plain, plain)
+
+	out := []byte{254, 9 /* AES-256 */, 3 /* iterated+salted */, 2 /* SHA1 */}
+	out = append(out, salt...)
+	out = append(out, countOctet)
+	out = append(out, iv...)
+	out = append(out, plain...)
+	return out
+}
+
+// s2kIterated derives keyLen key bytes from passphrase per RFC 4880
+// section 3.7.1.3, hashing salt||passphrase repeatedly (looping up to the
+// coded byte count) and, if one SHA-1 digest isn't enough key material,
+// re-hashing with an extra leading zero-octet context per RFC 4880bis.
+func s2kIterated(passphrase, salt []byte, countOctet byte, keyLen int) []byte {
+	count := (16 + int(countOctet&15)) << ((countOctet >> 4) + 6)
+	data := append(append([]byte{}, salt...), passphrase...)
+
+	var key []byte
+	for ctx := 0; len(key) < keyLen; ctx++ {
+		h := sha1.New()
+		for i := 0; i < ctx; i++ {
+			h.Write([]byte{0})
+		}
+		written := 0
+		for written < count {
+			n := len(data)
+			if written+n > count {
+				n = count - written
+			}
+			h.Write(data[:n])
+			written += n
+		}
+		key = append(key, h.Sum(nil)...)
+	}
+	return key[:keyLen]
+}
+
+func pgpArmor(blockType string, data []byte) []byte {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "-----BEGIN %s-----\n\n", blockType)
+	b64 := base64.StdEncoding.EncodeToString(data)
+	for i := 0; i < len(b64); i += 64 {
+		end := i + 64
+		if end > len(b64) {
+			end = len(b64)
+		}
+		buf.WriteString(b64[i:end])
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&buf, "=%s\n", base64.StdEncoding.EncodeToString(crc24(data)))
+	fmt.Fprintf(&buf, "-----END %s-----\n", blockType)
+	return []byte(buf.String())
+}
+
+func crc24(data []byte) []byte {
+	const (
+		init = 0xB704CE
+		poly = 0x1864CFB
+	)
+	crc := uint32(init)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= poly
+			}
+		}
+	}
+	crc &= 0xFFFFFF
+	return []byte{byte(crc >> 16), byte(crc >> 8), byte(crc)}
+}
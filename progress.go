@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// benchmarkRate samples this machine's single-core ed25519 keygen rate
+// over a short window, for the up-front ETA estimate.
+func benchmarkRate() float64 {
+	const sample = 100 * time.Millisecond
+	var n int
+	deadline := time.Now().Add(sample)
+	for time.Now().Before(deadline) {
+		ed25519.GenerateKey(rand.Reader)
+		n++
+	}
+	return float64(n) / sample.Seconds()
+}
+
+// sshBlobLen is the fixed wire length, in bytes, of a marshaled ed25519
+// SSH public key blob: 4+len("ssh-ed25519")+4+32. Ed25519 keys never
+// change size, so this never varies between runs.
+const sshBlobLen = 4 + len("ssh-ed25519") + 4 + 32
+
+const base64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// validateBase64Suffix rejects suffixes containing non-base64 characters,
+// and suffixes that could never occur because the low-order bits of the
+// final base64 character are forced to zero by padding. The latter only
+// bites when the encoded blob length isn't a multiple of 3 bytes; for the
+// fixed-size ed25519 blob here (sshBlobLen=51, divisible by 3) every
+// base64 character is fully aligned, so this degenerates to a no-op, but
+// it keeps the check correct if the wire format ever changes.
+func validateBase64Suffix(suffix string) error {
+	for _, c := range suffix {
+		if !strings.ContainsRune(base64Alphabet, c) {
+			return fmt.Errorf("suffix %q contains non-base64 character %q", suffix, c)
+		}
+	}
+
+	leftoverBits := (sshBlobLen * 8) % 6
+	if leftoverBits == 0 || len(suffix) == 0 {
+		return nil
+	}
+	// The last base64 character only has leftoverBits of real entropy;
+	// its low (6-leftoverBits) bits are always zero.
+	last := strings.IndexRune(base64Alphabet, rune(suffix[len(suffix)-1]))
+	if last&((1<<(6-leftoverBits))-1) != 0 {
+		return fmt.Errorf("suffix %q ends in a base64 character that padding can never produce", suffix)
+	}
+	return nil
+}
+
+// estimator is implemented by matchers that can estimate their own match
+// probability against a uniformly random key, so the progress bar can
+// combine them via a union bound regardless of matcher kind.
+type estimator interface {
+	probability() float64
+}
+
+func (m suffixBase64Matcher) probability() float64 {
+	alphabetSize := 64.0
+	if ignoreCase {
+		alphabetSize = 38.0 // case-folded base64: 10 digits + 26 letters + '+' '/'
+	}
+	return math.Pow(alphabetSize, -float64(len(m.suffix)))
+}
+
+func (m maskMatcher) probability() float64 {
+	return maskProbability(m.mask)
+}
+
+func (m containsHexMatcher) probability() float64 {
+	offsets := 32 - len(m.pattern) + 1
+	if offsets < 1 {
+		return 0
+	}
+	p := maskProbability(m.mask) * float64(offsets)
+	if p > 1 {
+		p = 1
+	}
+	return p
+}
+
+// maskProbability is the chance a random byte string satisfies a mask
+// comparison: each masked-in bit must match, so it's 1/2 per set mask bit.
+func maskProbability(mask []byte) float64 {
+	p := 1.0
+	for _, b := range mask {
+		p /= math.Pow(2, float64(popcount(b)))
+	}
+	return p
+}
+
+func popcount(b byte) int {
+	n := 0
+	for b != 0 {
+		n += int(b & 1)
+		b >>= 1
+	}
+	return n
+}
+
+// expectedTries estimates, via a union bound over the active matchers, the
+// expected number of randomly generated keys needed before one of them
+// matches.
+func expectedTries(matchers []Matcher) float64 {
+	var p float64
+	for _, m := range matchers {
+		if e, ok := m.(estimator); ok {
+			p += e.probability()
+		}
+	}
+	if p <= 0 {
+		return math.Inf(1)
+	}
+	return 1 / p
+}
+
+// humanScale formats n compactly using k/M/G/T/P suffixes.
+func humanScale(n float64) string {
+	units := []struct {
+		scale  float64
+		suffix string
+	}{
+		{1e15, "P"},
+		{1e12, "T"},
+		{1e9, "G"},
+		{1e6, "M"},
+		{1e3, "k"},
+	}
+	for _, u := range units {
+		if n >= u.scale {
+			return fmt.Sprintf("%.1f%s", n/u.scale, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%.0f", n)
+}
+
+// progressBar renders a fixed-width `[#######-----]` bar for fraction in [0,1].
+func progressBar(fraction float64, width int) string {
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	filled := int(fraction*float64(width) + 0.5)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+// progressLine renders the periodic ticker output: a progress bar and
+// percentage derived from the Poisson-process estimate
+// progress = 1 - exp(-counter/expected), plus an ETA based on the
+// current throughput.
+func progressLine(counter, hits int64, expected float64, elapsed time.Duration) string {
+	rate := float64(counter) / elapsed.Seconds()
+	fraction := 1 - math.Exp(-float64(counter)/expected)
+
+	eta := "?"
+	if rate > 0 {
+		remaining := expected - float64(counter)
+		if remaining < 0 {
+			remaining = 0
+		}
+		eta = time.Duration(remaining / rate * float64(time.Second)).Round(time.Second).String()
+	}
+
+	return fmt.Sprintf("\r\x1b[K%s %5.1f%%  %s tried (%s/sec), ETA %s, hit %d",
+		progressBar(fraction, 20), fraction*100, humanScale(float64(counter)), humanScale(rate), eta, hits)
+}